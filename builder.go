@@ -0,0 +1,217 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ruleKey identifies a declared transition rule by its source and
+// destination state, so Builder can detect rules declared more than once.
+type ruleKey struct {
+	from, to State
+}
+
+// Builder accumulates states, transitions, and hooks, and compiles them into
+// an immutable *Machine via Build. Unlike constructing a *Machine directly,
+// Build validates the entire state graph up front, so mistakes like a
+// transition to a state that was never registered are caught before the
+// machine is ever used, rather than at the first failing StateTransition
+// call.
+//
+// A Builder is not safe for concurrent use.
+type Builder struct {
+	m *Machine
+
+	initial    State
+	hasInitial bool
+
+	ruleCounts map[ruleKey]int
+}
+
+// NewBuilder returns a new, empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{m: &Machine{}}
+}
+
+// SetInitialState records state as the machine's initial state. Build fails
+// if SetInitialState is never called, or if state was never registered via
+// AddStateTransitionRules.
+func (b *Builder) SetInitialState(state State) error {
+	b.initial = state
+	b.hasInitial = true
+
+	return nil
+}
+
+// AddStateTransitionRules is a function for adding valid state transitions to
+// the machine being built. This allows you to define which states any given
+// state can be transitioned to.
+func (b *Builder) AddStateTransitionRules(sourceState State, destinationStates ...State) error {
+	if b.ruleCounts == nil {
+		b.ruleCounts = make(map[ruleKey]int)
+	}
+
+	for _, dest := range destinationStates {
+		b.ruleCounts[ruleKey{from: sourceState, to: dest}]++
+	}
+
+	return b.m.AddStateTransitionRules(sourceState, destinationStates...)
+}
+
+// AddEventTransition registers event as a valid trigger for moving the
+// machine being built from state from to state to. See Machine.AddEventTransition.
+func (b *Builder) AddEventTransition(event Event, from, to State, guard func(args ...interface{}) bool) error {
+	return b.m.AddEventTransition(event, from, to, guard)
+}
+
+// SetSubstateOf records state as a substate of parent. See Machine.SetSubstateOf.
+func (b *Builder) SetSubstateOf(state, parent State) error {
+	return b.m.SetSubstateOf(state, parent)
+}
+
+// OnEntry registers fn to run whenever the machine being built transitions
+// into state. See Machine.OnEntry.
+func (b *Builder) OnEntry(state State, fn func(from State) error) error {
+	return b.m.OnEntry(state, fn)
+}
+
+// OnExit registers fn to run whenever the machine being built transitions
+// out of state. See Machine.OnExit.
+func (b *Builder) OnExit(state State, fn func(to State) error) error {
+	return b.m.OnExit(state, fn)
+}
+
+// OnTransition registers fn to run for the specific from -> to transition.
+// See Machine.OnTransition.
+func (b *Builder) OnTransition(from, to State, fn func(from, to State) error) error {
+	return b.m.OnTransition(from, to, fn)
+}
+
+// BeforeTransition registers fn to run before every transition. See
+// Machine.BeforeTransition.
+func (b *Builder) BeforeTransition(fn func(from, to State) error) error {
+	return b.m.BeforeTransition(fn)
+}
+
+// AfterTransition registers fn to run after every transition has committed.
+// See Machine.AfterTransition.
+func (b *Builder) AfterTransition(fn func(from, to State) error) error {
+	return b.m.AfterTransition(fn)
+}
+
+// SetStateTransitionCallback for the machine being built. See
+// Machine.SetStateTransitionCallback.
+func (b *Builder) SetStateTransitionCallback(callback CallbackHandler, synchronous bool) error {
+	return b.m.SetStateTransitionCallback(callback, synchronous)
+}
+
+// SetStateStore wires store into the machine being built. See
+// Machine.SetStateStore.
+func (b *Builder) SetStateStore(store StateStore) error {
+	return b.m.SetStateStore(store)
+}
+
+// Build validates the accumulated state graph and, if it's sound, returns a
+// compiled, immutable *Machine set to its initial state. It fails with an
+// ErrorBuilderInvalid error, describing every problem found, if:
+//
+//   - no initial state was set, or the initial state was never registered
+//   - any transition's destination state was never registered
+//   - any state passed to SetSubstateOf as a parent was never registered
+//   - any registered state is unreachable from the initial state
+//   - the same from/to transition rule was declared more than once
+//
+// Once built, the returned *Machine rejects any further calls that would
+// mutate its rules or hooks; construct a new Builder to make a different
+// machine.
+func (b *Builder) Build() (*Machine, error) {
+	var problems []string
+
+	if !b.hasInitial {
+		problems = append(problems, "no initial state was set")
+	} else if _, ok := b.m.transitions[b.initial]; !ok {
+		problems = append(problems, fmt.Sprintf("initial state %s was never registered", b.initial))
+	}
+
+	for source, rules := range b.m.transitions {
+		for dest := range rules {
+			if _, ok := b.m.transitions[dest]; !ok {
+				problems = append(problems, fmt.Sprintf("state %s has a transition to unregistered state %s", source, dest))
+			}
+		}
+	}
+
+	for state, parent := range b.m.parents {
+		if _, ok := b.m.transitions[parent]; !ok {
+			problems = append(problems, fmt.Sprintf("state %s is a substate of unregistered state %s", state, parent))
+		}
+	}
+
+	for key, count := range b.ruleCounts {
+		if count > 1 {
+			problems = append(problems, fmt.Sprintf("transition from %s to %s was declared %d times", key.from, key.to, count))
+		}
+	}
+
+	if b.hasInitial {
+		reachable := make(map[State]bool)
+
+		// markReachable marks s reachable, along with every ancestor of s:
+		// being in a substate means also being in each of its ancestors, so
+		// an ancestor is reachable the moment any of its descendants is.
+		var markReachable func(State)
+		markReachable = func(s State) {
+			if reachable[s] {
+				return
+			}
+
+			reachable[s] = true
+
+			for _, ancestor := range b.m.ancestorChain(s) {
+				reachable[ancestor] = true
+			}
+		}
+
+		markReachable(b.initial)
+		queue := []State{b.initial}
+
+		for len(queue) > 0 {
+			state := queue[0]
+			queue = queue[1:]
+
+			// a substate can use any transition rule declared on its
+			// ancestors, so the same rules transitionAllowed honors at
+			// runtime must be walked here too
+			for _, ancestor := range b.m.ancestorChain(state) {
+				for dest := range b.m.transitions[ancestor] {
+					if !reachable[dest] {
+						markReachable(dest)
+						queue = append(queue, dest)
+					}
+				}
+			}
+		}
+
+		for state := range b.m.transitions {
+			if !reachable[state] {
+				problems = append(problems, fmt.Sprintf("state %s is not reachable from the initial state %s", state, b.initial))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, newErrorStruct(strings.Join(problems, "; "), ErrorBuilderInvalid)
+	}
+
+	if err := b.m.StateTransition(b.initial); err != nil {
+		return nil, err
+	}
+
+	b.m.compiled = true
+
+	return b.m, nil
+}
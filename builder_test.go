@@ -0,0 +1,147 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestBuilder_Build(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start", "started"), IsNil)
+	c.Assert(b.AddStateTransitionRules("started"), IsNil)
+	c.Assert(b.SetInitialState("start"), IsNil)
+
+	m, err := b.Build()
+	c.Assert(err, IsNil)
+
+	c.Check(m.CurrentState(), Equals, State("start"))
+
+	//
+	// a compiled machine can still transition...
+	//
+	err = m.StateTransition("started")
+	c.Assert(err, IsNil)
+
+	//
+	// ...but cannot have its rules mutated
+	//
+	err = m.AddStateTransitionRules("started", "start")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorMachineCompiled)
+}
+
+func (t *TestSuite) TestBuilder_Build_NoInitialState(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start"), IsNil)
+
+	_, err := b.Build()
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorBuilderInvalid)
+}
+
+func (t *TestSuite) TestBuilder_Build_DanglingDestination(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start", "nowhere"), IsNil)
+	c.Assert(b.SetInitialState("start"), IsNil)
+
+	_, err := b.Build()
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorBuilderInvalid)
+}
+
+func (t *TestSuite) TestBuilder_Build_Unreachable(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start"), IsNil)
+	c.Assert(b.AddStateTransitionRules("orphan"), IsNil)
+	c.Assert(b.SetInitialState("start"), IsNil)
+
+	_, err := b.Build()
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorBuilderInvalid)
+}
+
+func (t *TestSuite) TestBuilder_Build_SubstateReachability(c *C) {
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start", "child"), IsNil)
+	c.Assert(b.AddStateTransitionRules("child"), IsNil)
+	c.Assert(b.AddStateTransitionRules("parent", "exclusive_target"), IsNil)
+	c.Assert(b.AddStateTransitionRules("exclusive_target"), IsNil)
+	c.Assert(b.SetSubstateOf("child", "parent"), IsNil)
+	c.Assert(b.SetInitialState("start"), IsNil)
+
+	m, err := b.Build()
+	c.Assert(err, IsNil)
+
+	//
+	// child has no direct rule to exclusive_target, but it's a substate of
+	// parent, which does
+	//
+	c.Assert(m.StateTransition("child"), IsNil)
+
+	err = m.StateTransition("exclusive_target")
+	c.Assert(err, IsNil)
+
+	c.Check(m.CurrentState(), Equals, State("exclusive_target"))
+}
+
+func (t *TestSuite) TestBuilder_Build_UnregisteredSubstateParent(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("child", "child"), IsNil)
+	c.Assert(b.SetSubstateOf("child", "ghost_parent"), IsNil)
+	c.Assert(b.SetInitialState("child"), IsNil)
+
+	_, err := b.Build()
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorBuilderInvalid)
+}
+
+func (t *TestSuite) TestBuilder_Build_DuplicateRule(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	b := NewBuilder()
+	c.Assert(b.AddStateTransitionRules("start", "started"), IsNil)
+	c.Assert(b.AddStateTransitionRules("start", "started"), IsNil)
+	c.Assert(b.AddStateTransitionRules("started"), IsNil)
+	c.Assert(b.SetInitialState("start"), IsNil)
+
+	_, err := b.Build()
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorBuilderInvalid)
+}
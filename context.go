@@ -0,0 +1,42 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"time"
+)
+
+// ContextCallbackHandler is the context-aware variant of CallbackHandler. A
+// CallbackHandler registered with SetStateTransitionCallback that also
+// implements ContextCallbackHandler has StateTransitionContextCallback
+// invoked instead, whenever the transition was made via
+// StateTransitionContext, so it can observe the context the transition was
+// made with. It's a distinct method name from CallbackHandler's, rather
+// than an overload, so a single type can implement both interfaces.
+type ContextCallbackHandler interface {
+	StateTransitionContextCallback(ctx context.Context, state State) error
+}
+
+// detachedContext wraps a parent context.Context so that its values are
+// still reachable, but its cancellation and deadline are not: Done never
+// fires and Err is always nil. It's used to give an asynchronous callback a
+// context.Context for request-scoped values without tying the callback's
+// lifetime to a caller's context that may end before the callback is done.
+type detachedContext struct {
+	parent context.Context
+}
+
+// detach returns a copy of ctx with its cancellation and deadline removed.
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (d detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (d detachedContext) Done() <-chan struct{}       { return nil }
+func (d detachedContext) Err() error                  { return nil }
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type testContextKey string
+
+type testContextCallback struct {
+	mu    sync.Mutex
+	state State
+	value interface{}
+}
+
+// StateTransitionCallback satisfies CallbackHandler, so testContextCallback
+// can be registered with SetStateTransitionCallback. It's only invoked for
+// transitions made via the plain, non-context-aware StateTransition.
+func (tc *testContextCallback) StateTransitionCallback(s State) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.state = s
+	tc.value = nil
+
+	return nil
+}
+
+// StateTransitionContextCallback satisfies ContextCallbackHandler, and is
+// invoked instead of StateTransitionCallback for transitions made via
+// StateTransitionContext.
+func (tc *testContextCallback) StateTransitionContextCallback(ctx context.Context, s State) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.state = s
+	tc.value = ctx.Value(testContextKey("key"))
+
+	return nil
+}
+
+func (t *TestSuite) TestMachine_StateTransitionContext(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	tcc := &testContextCallback{}
+	c.Assert(t.m.SetStateTransitionCallback(tcc, true), IsNil)
+
+	ctx := context.WithValue(context.Background(), testContextKey("key"), "value")
+
+	err := t.m.StateTransitionContext(ctx, "start")
+	c.Assert(err, IsNil)
+
+	c.Check(t.m.CurrentState(), Equals, State("start"))
+
+	tcc.mu.Lock()
+	c.Check(tcc.state, Equals, State("start"))
+	c.Check(tcc.value, Equals, "value")
+	tcc.mu.Unlock()
+}
+
+func (t *TestSuite) TestMachine_StateTransition_PlainCallbackEvenWhenContextAware(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	tcc := &testContextCallback{}
+	c.Assert(t.m.SetStateTransitionCallback(tcc, true), IsNil)
+
+	// a plain StateTransition call must dispatch StateTransitionCallback,
+	// never StateTransitionContextCallback, even though tcc also
+	// implements ContextCallbackHandler
+	err := t.m.StateTransition("start")
+	c.Assert(err, IsNil)
+
+	tcc.mu.Lock()
+	c.Check(tcc.state, Equals, State("start"))
+	c.Check(tcc.value, IsNil)
+	tcc.mu.Unlock()
+}
+
+func (t *TestSuite) TestMachine_StateTransitionContext_Canceled(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := t.m.StateTransitionContext(ctx, "start")
+	c.Assert(err, Equals, context.Canceled)
+
+	c.Check(t.m.CurrentState(), Equals, State(""))
+}
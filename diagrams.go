@@ -0,0 +1,167 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExportDOT writes the machine's state graph to w in the Graphviz DOT
+// language. Nodes are the machine's registered states; edges are its
+// registered transitions, labeled with any events that trigger them. The
+// initial state is marked with an incoming arrow from a synthetic point
+// node, and the current state is styled as filled, following the
+// conventions of the Graphviz finite-state-machine examples.
+func (m *Machine) ExportDOT(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := m.sortedStates()
+	labels := m.eventEdgeLabels()
+
+	var err error
+	writeLine := func(format string, args ...interface{}) {
+		if err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w, format, args...)
+	}
+
+	writeLine("digraph fsm {\n")
+	writeLine("\trankdir=LR;\n")
+	writeLine("\tnode [shape=circle];\n")
+
+	if m.hasInitial {
+		writeLine("\n\t\"\" [shape=point];\n")
+		writeLine("\t\"\" -> %q;\n", m.initial)
+	}
+
+	if m.state != "" {
+		writeLine("\n\t%q [style=filled, fillcolor=lightblue];\n", m.state)
+	}
+
+	writeLine("\n")
+
+	// declare every registered state, so one with no transitions is drawn too
+	for _, s := range states {
+		writeLine("\t%q;\n", s)
+	}
+
+	writeLine("\n")
+
+	for _, from := range states {
+		for _, to := range m.sortedDests(from) {
+			if label, ok := labels[ruleKey{from: from, to: to}]; ok {
+				writeLine("\t%q -> %q [label=%q];\n", from, to, label)
+			} else {
+				writeLine("\t%q -> %q;\n", from, to)
+			}
+		}
+	}
+
+	writeLine("}\n")
+
+	return err
+}
+
+// ExportMermaid writes the machine's state graph to w as a Mermaid
+// stateDiagram-v2 definition. Nodes are the machine's registered states;
+// edges are its registered transitions, labeled with any events that
+// trigger them. The initial state is marked with the Mermaid [*] start
+// pseudostate, and the current state is assigned the "current" class.
+func (m *Machine) ExportMermaid(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := m.sortedStates()
+	labels := m.eventEdgeLabels()
+
+	var err error
+	writeLine := func(format string, args ...interface{}) {
+		if err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w, format, args...)
+	}
+
+	writeLine("stateDiagram-v2\n")
+
+	if m.hasInitial {
+		writeLine("\t[*] --> %s\n", m.initial)
+	}
+
+	// declare every registered state, so one with no transitions is drawn too
+	for _, s := range states {
+		writeLine("\t%s\n", s)
+	}
+
+	for _, from := range states {
+		for _, to := range m.sortedDests(from) {
+			if label, ok := labels[ruleKey{from: from, to: to}]; ok {
+				writeLine("\t%s --> %s : %s\n", from, to, label)
+			} else {
+				writeLine("\t%s --> %s\n", from, to)
+			}
+		}
+	}
+
+	if m.state != "" {
+		writeLine("\tclassDef current fill:#ADD8E6\n")
+		writeLine("\tclass %s current\n", m.state)
+	}
+
+	return err
+}
+
+// sortedStates returns the machine's registered states in lexical order, so
+// diagram output is deterministic. The caller must hold m.mu for reading or
+// writing.
+func (m *Machine) sortedStates() []State {
+	states := make([]State, 0, len(m.transitions))
+	for s := range m.transitions {
+		states = append(states, s)
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+
+	return states
+}
+
+// sortedDests returns from's destination states in lexical order. The
+// caller must hold m.mu for reading or writing.
+func (m *Machine) sortedDests(from State) []State {
+	dests := make([]State, 0, len(m.transitions[from]))
+	for d := range m.transitions[from] {
+		dests = append(dests, d)
+	}
+
+	sort.Slice(dests, func(i, j int) bool { return dests[i] < dests[j] })
+
+	return dests
+}
+
+// eventEdgeLabels returns, for every registered from/to transition that's
+// also reachable by one or more named events, a single comma-separated
+// label listing those events in lexical order. The caller must hold m.mu
+// for reading or writing.
+func (m *Machine) eventEdgeLabels() map[ruleKey]string {
+	byEdge := make(map[ruleKey][]string)
+
+	for key, rule := range m.events {
+		edge := ruleKey{from: key.state, to: rule.to}
+		byEdge[edge] = append(byEdge[edge], string(key.event))
+	}
+
+	labels := make(map[ruleKey]string, len(byEdge))
+	for edge, events := range byEdge {
+		sort.Strings(events)
+		labels[edge] = strings.Join(events, ",")
+	}
+
+	return labels
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"bytes"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestMachine_ExportDOT(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.AddEventTransition("go", "start", "started", nil), IsNil)
+	c.Assert(t.m.StateTransition("start"), IsNil)
+
+	var buf bytes.Buffer
+	err := t.m.ExportDOT(&buf)
+	c.Assert(err, IsNil)
+
+	out := buf.String()
+	c.Check(strings.HasPrefix(out, "digraph fsm {"), Equals, true)
+	c.Check(strings.Contains(out, `"" -> "start"`), Equals, true)
+	c.Check(strings.Contains(out, `"start" [style=filled, fillcolor=lightblue]`), Equals, true)
+	c.Check(strings.Contains(out, `"start" -> "started" [label="go"]`), Equals, true)
+	c.Check(strings.Contains(out, `"started" -> "finishing"`), Equals, true)
+}
+
+func (t *TestSuite) TestMachine_ExportMermaid(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.AddEventTransition("go", "start", "started", nil), IsNil)
+	c.Assert(t.m.StateTransition("start"), IsNil)
+
+	var buf bytes.Buffer
+	err := t.m.ExportMermaid(&buf)
+	c.Assert(err, IsNil)
+
+	out := buf.String()
+	c.Check(strings.HasPrefix(out, "stateDiagram-v2"), Equals, true)
+	c.Check(strings.Contains(out, "[*] --> start"), Equals, true)
+	c.Check(strings.Contains(out, "start --> started : go"), Equals, true)
+	c.Check(strings.Contains(out, "class start current"), Equals, true)
+}
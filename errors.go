@@ -19,6 +19,20 @@ func (e ErrorCode) String() string {
 		return "TransitionNotPermitted"
 	case ErrorStateUndefined:
 		return "StateUndefined"
+	case ErrorEventNotPermitted:
+		return "EventNotPermitted"
+	case ErrorEventGuardRejected:
+		return "EventGuardRejected"
+	case ErrorTransitionCanceled:
+		return "TransitionCanceled"
+	case ErrorSubstateCycle:
+		return "SubstateCycle"
+	case ErrorMachineCompiled:
+		return "MachineCompiled"
+	case ErrorBuilderInvalid:
+		return "BuilderInvalid"
+	case ErrorPersistenceFailed:
+		return "PersistenceFailed"
 	default:
 		return "Unknown"
 	}
@@ -41,8 +55,47 @@ const (
 	// ErrorStateUndefined is the error returned when the requested state is
 	// not defined within the machine.
 	ErrorStateUndefined
+
+	// ErrorEventNotPermitted is the error returned when firing an event that
+	// has no transition registered for the machine's current state.
+	ErrorEventNotPermitted
+
+	// ErrorEventGuardRejected is the error returned when an event's guard
+	// function rejects the transition based on the supplied arguments.
+	ErrorEventGuardRejected
+
+	// ErrorTransitionCanceled is the error returned when a BeforeTransition,
+	// OnExit, or OnTransition hook returns an error, canceling an in-flight
+	// transition before the machine's state is changed.
+	ErrorTransitionCanceled
+
+	// ErrorSubstateCycle is the error returned by SetSubstateOf when the
+	// requested parent/child relationship would introduce a cycle in the
+	// substate hierarchy.
+	ErrorSubstateCycle
+
+	// ErrorMachineCompiled is the error returned when a method that changes
+	// a *Machine's rules, hooks, callback, or state store is called on one
+	// produced by Builder.Build. A built machine can still transition between
+	// states; changing its rules means building a new one.
+	ErrorMachineCompiled
+
+	// ErrorBuilderInvalid is the error returned by Builder.Build when the
+	// accumulated state graph fails validation.
+	ErrorBuilderInvalid
+
+	// ErrorPersistenceFailed is the error returned when a configured
+	// StateStore fails to Load or Save state. When returned from
+	// StateTransition, the machine's in-memory state has been rolled back to
+	// what it was before the transition was attempted.
+	ErrorPersistenceFailed
 )
 
+// errMachineCompiled is returned by every Machine method that changes rules,
+// hooks, the callback, or the state store once the machine has been compiled
+// by Builder.Build.
+var errMachineCompiled = newErrorStruct("the machine was built with a Builder and cannot be mutated directly", ErrorMachineCompiled)
+
 // Error is the struct representing internal errors.
 // This implements the error interface
 type Error struct {
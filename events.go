@@ -0,0 +1,107 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Event is a named trigger for a state transition. It's really just a string.
+type Event string
+
+// eventKey identifies a registered event transition by the state it fires
+// from and the event that triggers it.
+type eventKey struct {
+	state State
+	event Event
+}
+
+// eventRule is the destination state and optional guard for an eventKey.
+type eventRule struct {
+	to    State
+	guard func(args ...interface{}) bool
+}
+
+// EventCallbackHandler is an interface type defining the interface for
+// receiving event callbacks. A CallbackHandler that also implements
+// EventCallbackHandler will have OnEvent invoked, in addition to
+// StateTransitionCallback, whenever a transition is driven through Fire.
+type EventCallbackHandler interface {
+	OnEvent(event Event, from, to State, args ...interface{})
+}
+
+// AddEventTransition registers event as a valid trigger for moving the
+// machine from state from to state to. This also registers the underlying
+// from -> to transition, so to must be reachable the same way it would be
+// with AddStateTransitionRules.
+//
+// guard is optional. If non-nil, it is evaluated with the arguments passed to
+// Fire before the transition is allowed to occur; if it returns false, the
+// transition is rejected with an ErrorEventGuardRejected error.
+func (m *Machine) AddEventTransition(event Event, from, to State, guard func(args ...interface{}) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	if m.events == nil {
+		m.events = make(map[eventKey]eventRule)
+	}
+
+	m.events[eventKey{state: from, event: event}] = eventRule{to: to, guard: guard}
+
+	m.addStateTransitionRules(from, to)
+
+	return nil
+}
+
+// Fire triggers the transition registered for event from the machine's
+// current state, passing args through to the guard and to any
+// EventCallbackHandler. It returns an ErrorEventNotPermitted error if no such
+// event is registered for the current state, an ErrorEventGuardRejected error
+// if the guard rejects the transition, or whatever error StateTransition
+// returns if the underlying transition fails.
+//
+// The state lookup, guard evaluation, and transition all happen under a
+// single hold of the machine's lock, so a concurrent Fire or StateTransition
+// call can't change the state out from under the guard between the lookup
+// and the commit.
+func (m *Machine) Fire(event Event, args ...interface{}) error {
+	m.mu.Lock()
+
+	from := m.state
+	rule, ok := m.events[eventKey{state: from, event: event}]
+	if !ok {
+		m.mu.Unlock()
+		return newErrorStruct(fmt.Sprintf("event %s is not permitted from state %s", event, from), ErrorEventNotPermitted)
+	}
+
+	if rule.guard != nil && !rule.guard(args...) {
+		m.mu.Unlock()
+		return newErrorStruct(fmt.Sprintf("guard rejected event %s from state %s", event, from), ErrorEventGuardRejected)
+	}
+
+	err := m.transitionLocked(context.Background(), rule.to, false)
+	callback, sync := m.callback, m.syncCallback
+
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if ech, ok := callback.(EventCallbackHandler); ok {
+		if sync {
+			ech.OnEvent(event, from, rule.to, args...)
+		} else {
+			go func() { ech.OnEvent(event, from, rule.to, args...) }()
+		}
+	}
+
+	return nil
+}
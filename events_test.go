@@ -0,0 +1,93 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type testEventCallback struct {
+	event    Event
+	from, to State
+	args     []interface{}
+}
+
+func (tec *testEventCallback) StateTransitionCallback(s State) error { return nil }
+
+func (tec *testEventCallback) OnEvent(event Event, from, to State, args ...interface{}) {
+	tec.event = event
+	tec.from = from
+	tec.to = to
+	tec.args = args
+}
+
+func (t *TestSuite) TestMachine_AddEventTransition(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	err := t.m.AddEventTransition("go", "start", "started", nil)
+	c.Assert(err, IsNil)
+
+	trs, err := t.m.StateTransitionRules("start")
+	c.Assert(err, IsNil)
+
+	_, ok := trs["started"]
+	c.Check(ok, Equals, true)
+}
+
+func (t *TestSuite) TestMachine_Fire(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	err := t.m.StateTransition("start")
+	c.Assert(err, IsNil)
+
+	//
+	// Test that firing an unregistered event fails
+	//
+	err = t.m.Fire("nope")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorEventNotPermitted)
+
+	//
+	// Test that a guard rejecting the event prevents the transition
+	//
+	err = t.m.AddEventTransition("go", "start", "started", func(args ...interface{}) bool {
+		return len(args) > 0 && args[0] == "allow"
+	})
+	c.Assert(err, IsNil)
+
+	err = t.m.Fire("go", "deny")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorEventGuardRejected)
+
+	c.Check(t.m.CurrentState(), Equals, State("start"))
+
+	//
+	// Test that a passing guard fires the transition and the event callback
+	//
+	tec := &testEventCallback{}
+
+	err = t.m.SetStateTransitionCallback(tec, true)
+	c.Assert(err, IsNil)
+
+	err = t.m.Fire("go", "allow")
+	c.Assert(err, IsNil)
+
+	c.Check(t.m.CurrentState(), Equals, State("started"))
+	c.Check(tec.event, Equals, Event("go"))
+	c.Check(tec.from, Equals, State("start"))
+	c.Check(tec.to, Equals, State("started"))
+	c.Check(tec.args, DeepEquals, []interface{}{"allow"})
+}
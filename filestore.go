@@ -0,0 +1,69 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a StateStore that persists state as plain text in a file on
+// disk.
+type FileStore struct {
+	// Path is the file the state is persisted to.
+	Path string
+}
+
+// NewFileStore returns a FileStore that persists state to path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the persisted state from disk. It returns "" without error if
+// Path does not yet exist.
+func (s *FileStore) Load(ctx context.Context) (State, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return State(data), nil
+}
+
+// Save persists state to disk. It writes to a temporary file alongside Path
+// and renames it into place, so a crash mid-write can never leave Path
+// holding a partial state.
+func (s *FileStore) Save(ctx context.Context, state State) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("fsm: failed to create temp file: %s", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write([]byte(state)); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("fsm: failed to write temp file: %s", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("fsm: failed to close temp file: %s", err)
+	}
+
+	if err := os.Rename(tmpPath, s.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("fsm: failed to rename temp file into place: %s", err)
+	}
+
+	return nil
+}
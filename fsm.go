@@ -8,6 +8,7 @@
 package fsm
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -46,8 +47,28 @@ type Machine struct {
 	transitions map[State]TransitionRuleSet
 	rules       map[State]map[State]State
 
+	parents map[State]State
+
+	events map[eventKey]eventRule
+
+	onEntry          map[State]func(from State) error
+	onExit           map[State]func(to State) error
+	onTransition     map[transitionKey]func(from, to State) error
+	beforeTransition func(from, to State) error
+	afterTransition  func(from, to State) error
+
 	callback     CallbackHandler
 	syncCallback bool
+
+	store StateStore
+
+	initial    State
+	hasInitial bool
+
+	// compiled is set by Builder.Build on the *Machine it returns. A
+	// compiled machine can still transition between states, but it can no
+	// longer have its rules or hooks mutated directly.
+	compiled bool
 }
 
 // CurrentState returns the machine's current state. If the State returned is
@@ -73,7 +94,16 @@ func (m *Machine) StateTransitionRules(state State) (TransitionRuleSet, error) {
 		return nil, newErrorStruct(fmt.Sprintf("state %s has not been registered", state), ErrorStateUndefined)
 	}
 
-	return m.transitions[state].Copy(), nil
+	// merge in any transitions inherited from the state's ancestors, if it
+	// has been declared a substate via SetSubstateOf
+	merged := make(TransitionRuleSet)
+	for _, ancestor := range m.ancestorChain(state) {
+		for dest := range m.transitions[ancestor] {
+			merged[dest] = struct{}{}
+		}
+	}
+
+	return merged, nil
 }
 
 // AddStateTransitionRules is a function for adding valid state transitions to the machine.
@@ -82,6 +112,18 @@ func (m *Machine) AddStateTransitionRules(sourceState State, destinationStates .
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	m.addStateTransitionRules(sourceState, destinationStates...)
+
+	return nil
+}
+
+// addStateTransitionRules records destinationStates as valid transitions from
+// sourceState. The caller must hold m.mu for writing.
+func (m *Machine) addStateTransitionRules(sourceState State, destinationStates ...State) {
 	// if the transitions map is nil, we need to allocate it
 	if m.transitions == nil {
 		m.transitions = make(map[State]TransitionRuleSet)
@@ -99,8 +141,6 @@ func (m *Machine) AddStateTransitionRules(sourceState State, destinationStates .
 	for _, dest := range destinationStates {
 		mp[dest] = struct{}{}
 	}
-
-	return nil
 }
 
 // SetStateTransitionCallback for the state transition. This is meant to send
@@ -111,6 +151,10 @@ func (m *Machine) SetStateTransitionCallback(callback CallbackHandler, synchrono
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.compiled {
+		return errMachineCompiled
+	}
+
 	m.callback = callback
 	m.syncCallback = synchronous
 
@@ -131,11 +175,38 @@ func (m *Machine) StateTransition(toState State) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.transitionLocked(context.Background(), toState, false)
+}
+
+// StateTransitionContext is the context-aware variant of StateTransition.
+// See StateTransition for the transition semantics; ctx is additionally
+// threaded through to any ContextCallbackHandler. If ctx is canceled or
+// times out before the transition commits, StateTransitionContext aborts
+// and returns ctx.Err() without changing the machine's state.
+func (m *Machine) StateTransitionContext(ctx context.Context, toState State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.transitionLocked(ctx, toState, true)
+}
+
+// transitionLocked is the shared implementation behind StateTransition and
+// StateTransitionContext. The caller must hold m.mu for writing; this lets
+// callers that need to evaluate their own state under the same lock (such
+// as Fire) drive the transition without a lock gap. The caller is expected
+// to supply a ctx appropriate for its StateStore, if one is configured.
+// useContextCallback is true only when the transition was driven through
+// StateTransitionContext; it gates whether a ContextCallbackHandler is
+// preferred over the plain CallbackHandler, so a type that implements both
+// still gets StateTransitionCallback from a plain StateTransition call.
+func (m *Machine) transitionLocked(ctx context.Context, toState State, useContextCallback bool) error {
 	// if this is nil we cannot assume any state
 	if m.transitions == nil {
 		return newErrorStruct("the machine has no states added", ErrorMachineNotInitialized)
 	}
 
+	from := m.state
+
 	// if the state is nothing, this is probably the initial state
 	if m.state == "" {
 		// if the state is not defined, it's invalid
@@ -143,33 +214,107 @@ func (m *Machine) StateTransition(toState State) error {
 			return newErrorStruct("the initial state has not been defined within the machine", ErrorStateUndefined)
 		}
 
-		// set the state
-		m.state = toState
-		return nil
+		m.initial = toState
+		m.hasInitial = true
+	} else {
+		// if we are not permitted to transition to this state, either
+		// directly or via one of from's ancestor states...
+		if !m.transitionAllowed(m.state, toState) {
+			return newErrorStruct(fmt.Sprintf("transition from state %s to %s is not permitted", m.state, toState), ErrorTransitionNotPermitted)
+		}
+
+		// if the destination state was not defined...
+		if _, ok := m.transitions[toState]; !ok {
+			return newErrorStruct(fmt.Sprintf("state %s has not been registered", toState), ErrorStateUndefined)
+		}
 	}
 
-	// if we are not permitted to transition to this state...
-	if _, ok := m.transitions[m.state][toState]; !ok {
-		return newErrorStruct(fmt.Sprintf("transition from state %s to %s is not permitted", m.state, toState), ErrorTransitionNotPermitted)
+	exitPath, enterPath := m.transitionPaths(from, toState)
+
+	// BeforeTransition -> OnExit(from..lca) -> OnTransition(from, to) may
+	// each cancel the transition before the state is mutated.
+	if m.beforeTransition != nil {
+		if err := m.beforeTransition(from, toState); err != nil {
+			return canceledTransitionError(from, toState, err)
+		}
+	}
+
+	for _, state := range exitPath {
+		if fn, ok := m.onExit[state]; ok {
+			if err := fn(toState); err != nil {
+				return canceledTransitionError(from, toState, err)
+			}
+		}
 	}
 
-	// if the destination state was not defined...
-	if _, ok := m.transitions[toState]; !ok {
-		return newErrorStruct(fmt.Sprintf("state %s has not been registered", toState), ErrorStateUndefined)
+	if fn, ok := m.onTransition[transitionKey{from: from, to: toState}]; ok {
+		if err := fn(from, toState); err != nil {
+			return canceledTransitionError(from, toState, err)
+		}
+	}
+
+	// give the caller's context one last chance to cancel the transition
+	// before it's committed; honoring it any later would mean unwinding a
+	// transition that's already taken effect
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
 	}
 
 	m.state = toState
 
+	if m.store != nil {
+		if err := m.store.Save(ctx, toState); err != nil {
+			// persistence is transactional with the transition: roll back
+			// the in-memory state so the two never disagree
+			m.state = from
+			return newErrorStruct(fmt.Sprintf("failed to persist state %s: %s", toState, err), ErrorPersistenceFailed)
+		}
+	}
+
+	// the transition has committed; OnEntry, the legacy callback, and
+	// AfterTransition all run after the fact and cannot cancel it.
+	for _, state := range enterPath {
+		if fn, ok := m.onEntry[state]; ok {
+			// do not return the error
+			// this may be reconsidered
+			fn(from)
+		}
+	}
+
 	if m.callback != nil {
+		cch, hasContextCallback := m.callback.(ContextCallbackHandler)
+		useContextCallback := useContextCallback && hasContextCallback
+
 		if m.syncCallback {
 			// do not return the error
 			// this may be reconsidered
-			m.callback.StateTransitionCallback(toState)
+			if useContextCallback {
+				cch.StateTransitionContextCallback(ctx, toState)
+			} else {
+				m.callback.StateTransitionCallback(toState)
+			}
 		} else {
-			// spin off the callback
-			go func() { m.callback.StateTransitionCallback(toState) }()
+			// spin off the callback; detach it from ctx's cancellation and
+			// deadline so the caller ending its own request doesn't cut the
+			// callback short, while it can still read values out of ctx
+			detached := detach(ctx)
+			go func() {
+				if useContextCallback {
+					cch.StateTransitionContextCallback(detached, toState)
+				} else {
+					m.callback.StateTransitionCallback(toState)
+				}
+			}()
 		}
 	}
 
+	if m.afterTransition != nil {
+		// do not return the error
+		// this may be reconsidered
+		m.afterTransition(from, toState)
+	}
+
 	return nil
 }
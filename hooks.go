@@ -0,0 +1,125 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import "fmt"
+
+// transitionKey identifies a registered per-transition hook by the states it
+// runs between.
+type transitionKey struct {
+	from, to State
+}
+
+// OnEntry registers fn to run whenever the machine transitions into state,
+// including when state is set as the machine's initial state. fn receives
+// the state the machine is transitioning from, which is "" for the initial
+// transition. Only one OnEntry hook may be registered per state; a later
+// call for the same state replaces the earlier one.
+func (m *Machine) OnEntry(state State, fn func(from State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	if m.onEntry == nil {
+		m.onEntry = make(map[State]func(from State) error)
+	}
+
+	m.onEntry[state] = fn
+
+	return nil
+}
+
+// OnExit registers fn to run whenever the machine transitions out of state.
+// fn receives the state the machine is transitioning to. If fn returns an
+// error, the transition is aborted and the machine's state is left
+// unchanged; StateTransition returns that error wrapped in an
+// ErrorTransitionCanceled error. Only one OnExit hook may be registered per
+// state; a later call for the same state replaces the earlier one.
+func (m *Machine) OnExit(state State, fn func(to State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	if m.onExit == nil {
+		m.onExit = make(map[State]func(to State) error)
+	}
+
+	m.onExit[state] = fn
+
+	return nil
+}
+
+// OnTransition registers fn to run for the specific from -> to transition,
+// after the OnExit(from) hook and before the machine's state is changed. If
+// fn returns an error, the transition is aborted and the machine's state is
+// left unchanged; StateTransition returns that error wrapped in an
+// ErrorTransitionCanceled error. Only one OnTransition hook may be
+// registered per from/to pair; a later call for the same pair replaces the
+// earlier one.
+func (m *Machine) OnTransition(from, to State, fn func(from, to State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	if m.onTransition == nil {
+		m.onTransition = make(map[transitionKey]func(from, to State) error)
+	}
+
+	m.onTransition[transitionKey{from: from, to: to}] = fn
+
+	return nil
+}
+
+// BeforeTransition registers fn to run before every transition, ahead of any
+// OnExit or OnTransition hook. If fn returns an error, the transition is
+// aborted and the machine's state is left unchanged; StateTransition returns
+// that error wrapped in an ErrorTransitionCanceled error. Only one
+// BeforeTransition hook may be registered at a time; a later call replaces
+// the earlier one.
+func (m *Machine) BeforeTransition(fn func(from, to State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	m.beforeTransition = fn
+
+	return nil
+}
+
+// AfterTransition registers fn to run after every transition has committed,
+// following the destination state's OnEntry hook. Its return value is
+// ignored, since the transition has already taken effect by the time it
+// runs. Only one AfterTransition hook may be registered at a time; a later
+// call replaces the earlier one.
+func (m *Machine) AfterTransition(fn func(from, to State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	m.afterTransition = fn
+
+	return nil
+}
+
+// canceledTransitionError wraps err, returned by a before/exit/transition
+// hook, in an ErrorTransitionCanceled *Error.
+func canceledTransitionError(from, to State, err error) *Error {
+	return newErrorStruct(fmt.Sprintf("transition from %s to %s was canceled by a hook: %s", from, to, err), ErrorTransitionCanceled)
+}
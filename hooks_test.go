@@ -0,0 +1,80 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestMachine_Hooks_Order(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	var order []string
+
+	c.Assert(t.m.BeforeTransition(func(from, to State) error {
+		order = append(order, "before")
+		return nil
+	}), IsNil)
+
+	c.Assert(t.m.OnExit("start", func(to State) error {
+		order = append(order, "exit")
+		return nil
+	}), IsNil)
+
+	c.Assert(t.m.OnTransition("start", "started", func(from, to State) error {
+		order = append(order, "transition")
+		return nil
+	}), IsNil)
+
+	c.Assert(t.m.OnEntry("started", func(from State) error {
+		order = append(order, "entry")
+		return nil
+	}), IsNil)
+
+	c.Assert(t.m.AfterTransition(func(from, to State) error {
+		order = append(order, "after")
+		return nil
+	}), IsNil)
+
+	c.Assert(t.m.StateTransition("start"), IsNil)
+
+	// the initial transition also runs BeforeTransition/AfterTransition
+	// (they're not scoped to a specific from/to); reset order so the
+	// assertion below only covers the transition under test
+	order = nil
+
+	c.Assert(t.m.StateTransition("started"), IsNil)
+
+	c.Check(order, DeepEquals, []string{"before", "exit", "transition", "entry", "after"})
+}
+
+func (t *TestSuite) TestMachine_Hooks_Cancel(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.StateTransition("start"), IsNil)
+
+	//
+	// OnExit canceling the transition leaves the state unchanged
+	//
+	c.Assert(t.m.OnExit("start", func(to State) error {
+		return errors.New("nope")
+	}), IsNil)
+
+	err := t.m.StateTransition("started")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorTransitionCanceled)
+
+	c.Check(t.m.CurrentState(), Equals, State("start"))
+}
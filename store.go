@@ -0,0 +1,118 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// StateStore is the interface a pluggable storage backend must implement to
+// persist a Machine's state across process restarts. Load returns "" if no
+// state has been saved yet.
+type StateStore interface {
+	Load(ctx context.Context) (State, error)
+	Save(ctx context.Context, state State) error
+}
+
+// StoreFunc adapts a pair of load/save functions to the StateStore
+// interface, in the same spirit as http.HandlerFunc.
+type StoreFunc struct {
+	LoadFunc func(ctx context.Context) (State, error)
+	SaveFunc func(ctx context.Context, state State) error
+}
+
+// Load calls f.LoadFunc.
+func (f StoreFunc) Load(ctx context.Context) (State, error) { return f.LoadFunc(ctx) }
+
+// Save calls f.SaveFunc.
+func (f StoreFunc) Save(ctx context.Context, state State) error { return f.SaveFunc(ctx, state) }
+
+// MemoryStore is a StateStore that keeps the persisted state in memory. It's
+// primarily useful for testing; it provides no durability across process
+// restarts.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	state State
+}
+
+// Load returns the last state saved with Save, or "" if Save has not yet
+// been called.
+func (s *MemoryStore) Load(ctx context.Context) (State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state, nil
+}
+
+// Save records state as the current state.
+func (s *MemoryStore) Save(ctx context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state
+
+	return nil
+}
+
+// SetStateStore wires store into the machine. Once configured, every
+// successful StateTransition persists the new state through store right
+// after the state is mutated: after BeforeTransition, OnExit, and
+// OnTransition have already run (so they can still cancel the transition
+// and never observe the persisted value), but before OnEntry, the
+// callback, and AfterTransition run. If store.Save fails, the transition
+// is rolled back and StateTransition returns the failure wrapped in an
+// ErrorPersistenceFailed error.
+func (m *Machine) SetStateStore(store StateStore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	m.store = store
+
+	return nil
+}
+
+// Restore seeds the machine's current state from its configured StateStore,
+// for reviving a machine after a process restart. It returns an
+// ErrorMachineNotInitialized error if no StateStore has been configured, an
+// ErrorPersistenceFailed error if the store's Load fails, and an
+// ErrorStateUndefined error if the persisted state was never registered
+// with the machine. If the store has no persisted state yet, Restore is a
+// no-op.
+func (m *Machine) Restore(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.store == nil {
+		return newErrorStruct("no state store has been configured", ErrorMachineNotInitialized)
+	}
+
+	state, err := m.store.Load(ctx)
+	if err != nil {
+		return newErrorStruct(fmt.Sprintf("failed to load persisted state: %s", err), ErrorPersistenceFailed)
+	}
+
+	if state == "" {
+		return nil
+	}
+
+	if _, ok := m.transitions[state]; !ok {
+		return newErrorStruct(fmt.Sprintf("persisted state %s has not been registered", state), ErrorStateUndefined)
+	}
+
+	if !m.hasInitial {
+		m.initial = state
+		m.hasInitial = true
+	}
+
+	m.state = state
+
+	return nil
+}
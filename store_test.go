@@ -0,0 +1,144 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestMemoryStore(c *C) {
+	s := &MemoryStore{}
+	ctx := context.Background()
+
+	state, err := s.Load(ctx)
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State(""))
+
+	c.Assert(s.Save(ctx, "started"), IsNil)
+
+	state, err = s.Load(ctx)
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State("started"))
+}
+
+func (t *TestSuite) TestStoreFunc(c *C) {
+	var saved State
+
+	sf := StoreFunc{
+		LoadFunc: func(ctx context.Context) (State, error) { return saved, nil },
+		SaveFunc: func(ctx context.Context, state State) error { saved = state; return nil },
+	}
+
+	c.Assert(sf.Save(context.Background(), "started"), IsNil)
+
+	state, err := sf.Load(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State("started"))
+}
+
+func (t *TestSuite) TestMachine_SetStateStore_PersistsOnTransition(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	store := &MemoryStore{}
+	c.Assert(t.m.SetStateStore(store), IsNil)
+
+	c.Assert(t.m.StateTransition("start"), IsNil)
+
+	state, err := store.Load(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State("start"))
+
+	//
+	// a failing Save rolls back the in-memory state
+	//
+	failing := StoreFunc{
+		LoadFunc: func(ctx context.Context) (State, error) { return "", nil },
+		SaveFunc: func(ctx context.Context, state State) error { return errors.New("disk full") },
+	}
+	c.Assert(t.m.SetStateStore(failing), IsNil)
+
+	err = t.m.StateTransition("started")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorPersistenceFailed)
+
+	c.Check(t.m.CurrentState(), Equals, State("start"))
+}
+
+func (t *TestSuite) TestMachine_Restore(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	//
+	// restoring without a configured store fails
+	//
+	err := t.m.Restore(context.Background())
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorMachineNotInitialized)
+
+	//
+	// restoring an unregistered state fails
+	//
+	store := &MemoryStore{}
+	c.Assert(store.Save(context.Background(), "never_seen"), IsNil)
+	c.Assert(t.m.SetStateStore(store), IsNil)
+
+	err = t.m.Restore(context.Background())
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorStateUndefined)
+
+	//
+	// restoring a registered state succeeds
+	//
+	c.Assert(store.Save(context.Background(), "started"), IsNil)
+
+	err = t.m.Restore(context.Background())
+	c.Assert(err, IsNil)
+
+	c.Check(t.m.CurrentState(), Equals, State("started"))
+}
+
+func (t *TestSuite) TestFileStore(c *C) {
+	dir, err := ioutil.TempDir("", "fsm-filestore")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "state"))
+	ctx := context.Background()
+
+	//
+	// loading before anything has been saved returns ""
+	//
+	state, err := store.Load(ctx)
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State(""))
+
+	c.Assert(store.Save(ctx, "started"), IsNil)
+
+	state, err = store.Load(ctx)
+	c.Assert(err, IsNil)
+	c.Check(state, Equals, State("started"))
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import "fmt"
+
+// SetSubstateOf records state as a substate of parent. Once set, any
+// transition rule defined on parent (or one of parent's own ancestors) is
+// also a valid transition for state, and IsInState(parent) returns true
+// whenever the machine is in state or one of its descendants.
+//
+// SetSubstateOf returns an ErrorSubstateCycle error if parent is already a
+// descendant of state, since that would introduce a cycle in the hierarchy.
+func (m *Machine) SetSubstateOf(state, parent State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.compiled {
+		return errMachineCompiled
+	}
+
+	for _, ancestor := range m.ancestorChain(parent) {
+		if ancestor == state {
+			return newErrorStruct(fmt.Sprintf("setting %s as a substate of %s would introduce a cycle", state, parent), ErrorSubstateCycle)
+		}
+	}
+
+	if m.parents == nil {
+		m.parents = make(map[State]State)
+	}
+
+	m.parents[state] = parent
+
+	return nil
+}
+
+// IsInState returns true if the machine's current state is s, or if s is an
+// ancestor of the machine's current state by way of SetSubstateOf.
+func (m *Machine) IsInState(s State) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, ancestor := range m.ancestorChain(m.state) {
+		if ancestor == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ancestorChain returns state followed by each of its ancestors, in order
+// from state up to its root. The caller must hold m.mu for reading or
+// writing.
+func (m *Machine) ancestorChain(state State) []State {
+	chain := []State{state}
+
+	for {
+		parent, ok := m.parents[state]
+		if !ok {
+			return chain
+		}
+
+		chain = append(chain, parent)
+		state = parent
+	}
+}
+
+// transitionAllowed reports whether to is a valid destination from, as
+// defined on from or any of its ancestors. The caller must hold m.mu for
+// reading or writing.
+func (m *Machine) transitionAllowed(from, to State) bool {
+	for _, ancestor := range m.ancestorChain(from) {
+		if _, ok := m.transitions[ancestor][to]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transitionPaths returns the states to exit, from the leaf up to (but not
+// including) the lowest common ancestor of from and to, and the states to
+// enter, from just below that ancestor down to to. When from and to share
+// no ancestor, every ancestor of from is exited and every ancestor of to is
+// entered. The caller must hold m.mu for reading or writing.
+func (m *Machine) transitionPaths(from, to State) (exitPath, enterPath []State) {
+	fromChain := m.ancestorChain(from)
+	toChain := m.ancestorChain(to)
+
+	lcaIndex := make(map[State]int, len(toChain))
+	for i, s := range toChain {
+		lcaIndex[s] = i
+	}
+
+	exitPath = fromChain
+	enterPath = toChain
+
+	for i, s := range fromChain {
+		if j, ok := lcaIndex[s]; ok {
+			exitPath = fromChain[:i]
+			enterPath = toChain[:j]
+			break
+		}
+	}
+
+	reversed := make([]State, len(enterPath))
+	for i, s := range enterPath {
+		reversed[len(enterPath)-1-i] = s
+	}
+
+	return exitPath, reversed
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package fsm
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestMachine_SetSubstateOf(c *C) {
+	var ok bool
+	var fsmErr *Error
+
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.AddStateTransitionRules("working", "finishing"), IsNil)
+	c.Assert(t.m.AddStateTransitionRules("working_hard"), IsNil)
+
+	err := t.m.SetSubstateOf("working_hard", "working")
+	c.Assert(err, IsNil)
+
+	//
+	// Test that a cycle is rejected
+	//
+	err = t.m.SetSubstateOf("working", "working_hard")
+	c.Assert(err, NotNil)
+
+	fsmErr, ok = err.(*Error)
+	c.Assert(ok, Equals, true)
+	c.Check(fsmErr.Code(), Equals, ErrorSubstateCycle)
+}
+
+func (t *TestSuite) TestMachine_IsInState(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.AddStateTransitionRules("working", "finishing"), IsNil)
+	c.Assert(t.m.AddStateTransitionRules("working_hard"), IsNil)
+	c.Assert(t.m.AddStateTransitionRules("start", "working_hard"), IsNil)
+	c.Assert(t.m.SetSubstateOf("working_hard", "working"), IsNil)
+
+	c.Assert(t.m.StateTransition("start"), IsNil)
+	c.Assert(t.m.StateTransition("working_hard"), IsNil)
+
+	c.Check(t.m.IsInState("working_hard"), Equals, true)
+	c.Check(t.m.IsInState("working"), Equals, true)
+	c.Check(t.m.IsInState("finishing"), Equals, false)
+}
+
+func (t *TestSuite) TestMachine_Substate_InheritsTransitions(c *C) {
+	// reset the machine
+	defer t.setUpMachine(c)
+
+	c.Assert(t.m.AddStateTransitionRules("working", "finishing"), IsNil)
+	c.Assert(t.m.AddStateTransitionRules("working_hard"), IsNil)
+	c.Assert(t.m.AddStateTransitionRules("start", "working_hard"), IsNil)
+	c.Assert(t.m.SetSubstateOf("working_hard", "working"), IsNil)
+
+	c.Assert(t.m.StateTransition("start"), IsNil)
+	c.Assert(t.m.StateTransition("working_hard"), IsNil)
+
+	//
+	// working_hard has no direct rule to finishing, but it's a substate of
+	// working, which does
+	//
+	err := t.m.StateTransition("finishing")
+	c.Assert(err, IsNil)
+
+	c.Check(t.m.CurrentState(), Equals, State("finishing"))
+}